@@ -0,0 +1,82 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package config holds the feature names and runtime knobs shared by the
+// collectors and the power estimators. Values here are read at process
+// start-up from flags/env vars by the main command; packages under
+// pkg/model should only ever read them.
+package config
+
+import "time"
+
+// Feature names used to index into the numerical feature vectors handed to
+// the power estimators. These must match the names the Kepler Model Server
+// trains its weights against.
+const (
+	CPUCycle               = "cpu_cycles"
+	CPUInstruction         = "cpu_instructions"
+	CacheMiss              = "cache_miss"
+	CgroupfsMemory         = "cgroupfs_memory_usage_bytes"
+	CgroupfsKernelMemory   = "cgroupfs_kernel_memory_usage_bytes"
+	CgroupfsTCPMemory      = "cgroupfs_tcp_memory_usage_bytes"
+	CgroupfsCPU            = "cgroupfs_cpu_usage_us"
+	CgroupfsSystemCPU      = "cgroupfs_system_cpu_usage_us"
+	CgroupfsUserCPU        = "cgroupfs_user_cpu_usage_us"
+	CgroupfsReadIO         = "cgroupfs_ioread_bytes"
+	CgroupfsWriteIO        = "cgroupfs_iowrite_bytes"
+	BlockDevicesIO         = "block_devices_used"
+	KubeletContainerCPU    = "container_cpu_usage_seconds_total"
+	KubeletContainerMemory = "container_memory_working_set_bytes"
+	KubeletNodeCPU         = "node_cpu_usage_seconds_total"
+	KubeletNodeMemory      = "node_memory_working_set_bytes"
+)
+
+var (
+	// ModelServerEnable toggles fetching model weights from the Kepler
+	// Model Server instead of a static ModelWeightsURL.
+	ModelServerEnable bool
+	// ModelServerEndpoint is the base URL of the Kepler Model Server.
+	ModelServerEndpoint string
+	// EstimatorModel selects which PowerEstimator backend (see
+	// types.EstimatorType) the collector builds, e.g. "linear",
+	// "neuralnet" or "gbt". Defaults to "linear" for backward
+	// compatibility with existing deployments and model weight files.
+	EstimatorModel = "linear"
+	// ModelCacheDir is where fetched model weights are cached on disk,
+	// keyed by a hash of the output type and source endpoint/URL.
+	ModelCacheDir = "/var/lib/kepler/models"
+	// ModelCacheTTL is how long a cached copy is trusted before Start()
+	// attempts to refresh it from the network. A failed refresh (or
+	// ModelCacheOffline) falls back to the stale cached copy instead of
+	// failing outright.
+	ModelCacheTTL = 24 * time.Hour
+	// ModelCacheOffline, when true, skips the network fetch entirely and
+	// serves the cached copy, if one exists.
+	ModelCacheOffline bool
+	// EstimatorChainEndpoints is a comma-separated, ordered list of Model
+	// Server / initial-model URLs an EstimatorChain should try, falling
+	// through to the next one on failure. Each entry is "modelserver=<url>"
+	// or "staticurl=<url>" (an initial-model weights file); a bare URL is
+	// treated as a Model Server endpoint.
+	EstimatorChainEndpoints string
+	// EstimatorChainPolicy selects how an EstimatorChain combines its
+	// estimators: "first-healthy" (default), "average" or
+	// "weighted-average".
+	EstimatorChainPolicy = "first-healthy"
+	// EstimatorChainCooldown is how long a demoted estimator is skipped
+	// before an EstimatorChain retries it.
+	EstimatorChainCooldown = 30 * time.Second
+)
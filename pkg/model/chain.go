@@ -0,0 +1,385 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+chain.go
+EstimatorChain wraps an ordered list of local.PowerEstimator and is itself a
+local.PowerEstimator, so a collector that wants "try the Model Server, then
+an initial-model URL, then a static fallback" can build a chain once and use
+it exactly like any single estimator. A typical chain is a primary
+LinearRegressor/NeuralNetRegressor pointed at the Model Server, a secondary
+one pointed at a static ModelWeightsURL, and a last, always-healthy static
+fallback estimator.
+*/
+
+package model
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sustainable-computing-io/kepler/pkg/config"
+	"github.com/sustainable-computing-io/kepler/pkg/model/estimator/local"
+	"github.com/sustainable-computing-io/kepler/pkg/model/types"
+)
+
+// HealthPolicy selects how EstimatorChain turns its members' predictions
+// into a single result.
+type HealthPolicy string
+
+const (
+	// FirstHealthy returns the first healthy estimator's prediction.
+	FirstHealthy HealthPolicy = "first-healthy"
+	// Average returns the unweighted mean of every healthy estimator's
+	// prediction.
+	Average HealthPolicy = "average"
+	// WeightedAverage returns the mean of every healthy estimator's
+	// prediction, weighted by the inverse square of its
+	// EstimatorChainMember.Residual.
+	WeightedAverage HealthPolicy = "weighted-average"
+)
+
+// EstimatorChainMember is one estimator in a chain plus the metadata the
+// chain needs to weight and demote it.
+type EstimatorChainMember struct {
+	Estimator local.PowerEstimator
+	// Residual is this estimator's known historical error (e.g. the
+	// trainer's reported RMSE). It only matters under WeightedAverage; zero
+	// means "unknown" and falls back to equal weighting.
+	Residual float64
+}
+
+type chainMember struct {
+	EstimatorChainMember
+	healthy   bool
+	demotedAt time.Time
+}
+
+// EstimatorChain tries its members in order (FirstHealthy) or combines them
+// (Average/WeightedAverage), skipping any member that errored or returned
+// NaN until Cooldown has passed since it was demoted.
+type EstimatorChain struct {
+	Policy   HealthPolicy
+	Cooldown time.Duration
+
+	mu      sync.Mutex
+	members []*chainMember
+}
+
+var _ local.PowerEstimator = (*EstimatorChain)(nil)
+
+// NewEstimatorChain builds a chain that tries members in order.
+func NewEstimatorChain(policy HealthPolicy, cooldown time.Duration, members ...EstimatorChainMember) *EstimatorChain {
+	chainMembers := make([]*chainMember, len(members))
+	for i, m := range members {
+		chainMembers[i] = &chainMember{EstimatorChainMember: m, healthy: true}
+	}
+	return &EstimatorChain{Policy: policy, Cooldown: cooldown, members: chainMembers}
+}
+
+// Start initializes every member and records its health; it only fails if
+// every member fails to start.
+func (c *EstimatorChain) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var lastErr error
+	healthyCount := 0
+	for _, m := range c.members {
+		if err := m.Estimator.Start(); err != nil {
+			m.healthy = false
+			m.demotedAt = time.Now()
+			lastErr = err
+			continue
+		}
+		m.healthy = true
+		healthyCount++
+	}
+	if healthyCount == 0 {
+		return fmt.Errorf("estimator chain: no member started successfully: %w", lastErr)
+	}
+	return nil
+}
+
+// ResetSampleIdx forwards to every member so whichever one ends up healthy
+// at prediction time has the samples it needs.
+func (c *EstimatorChain) ResetSampleIdx() {
+	for _, m := range c.members {
+		m.Estimator.ResetSampleIdx()
+	}
+}
+
+// AddNodeFeatureValues forwards to every member.
+func (c *EstimatorChain) AddNodeFeatureValues(values []float64) {
+	for _, m := range c.members {
+		m.Estimator.AddNodeFeatureValues(values)
+	}
+}
+
+// AddContainerFeatureValues forwards to every member.
+func (c *EstimatorChain) AddContainerFeatureValues(values []float64) {
+	for _, m := range c.members {
+		m.Estimator.AddContainerFeatureValues(values)
+	}
+}
+
+// GetPlatformPower returns a platform power estimate per c.Policy.
+func (c *EstimatorChain) GetPlatformPower(isIdlePower bool) ([]float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.Policy == Average || c.Policy == WeightedAverage {
+		return combine(c, func(m *chainMember) ([]float64, error) {
+			return m.Estimator.GetPlatformPower(isIdlePower)
+		}, sumFloat, scaleFloat, hasNaNFloat)
+	}
+	powers, err := firstHealthy(c, func(m *chainMember) ([]float64, error) {
+		return m.Estimator.GetPlatformPower(isIdlePower)
+	}, hasNaNFloat)
+	return powers, err
+}
+
+// GetComponentsPower returns a per-component power estimate per c.Policy.
+func (c *EstimatorChain) GetComponentsPower(isIdlePower bool) ([]local.ComponentPower, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.Policy == Average || c.Policy == WeightedAverage {
+		return combine(c, func(m *chainMember) ([]local.ComponentPower, error) {
+			return m.Estimator.GetComponentsPower(isIdlePower)
+		}, sumComponents, scaleComponents, hasNaNComponents)
+	}
+	return firstHealthy(c, func(m *chainMember) ([]local.ComponentPower, error) {
+		return m.Estimator.GetComponentsPower(isIdlePower)
+	}, hasNaNComponents)
+}
+
+// maybeRecover lazily re-probes a demoted member once its cooldown has
+// elapsed, instead of spinning a background goroutine per member.
+func (c *EstimatorChain) maybeRecover(m *chainMember) {
+	if m.healthy || time.Since(m.demotedAt) < c.Cooldown {
+		return
+	}
+	if err := m.Estimator.Start(); err != nil {
+		m.demotedAt = time.Now()
+		return
+	}
+	m.healthy = true
+}
+
+func (c *EstimatorChain) demote(m *chainMember) {
+	m.healthy = false
+	m.demotedAt = time.Now()
+}
+
+// firstHealthy returns the first healthy, non-erroring, non-NaN result from
+// get, demoting any member it skips over along the way.
+func firstHealthy[T any](c *EstimatorChain, get func(*chainMember) (T, error), isInvalid func(T) bool) (T, error) {
+	var lastErr error
+	for _, m := range c.members {
+		c.maybeRecover(m)
+		if !m.healthy {
+			continue
+		}
+		result, err := get(m)
+		if err != nil || isInvalid(result) {
+			c.demote(m)
+			lastErr = err
+			continue
+		}
+		return result, nil
+	}
+	var zero T
+	if lastErr == nil {
+		lastErr = fmt.Errorf("estimator chain: no healthy estimator available")
+	}
+	return zero, lastErr
+}
+
+// combine averages get's result across every healthy member, weighting by
+// the inverse square of EstimatorChainMember.Residual under WeightedAverage.
+func combine[T any](c *EstimatorChain, get func(*chainMember) (T, error), sum func(acc, v T, weight float64) T, scale func(v T, weight float64) T, isInvalid func(T) bool) (T, error) {
+	var acc T
+	var weightSum float64
+	have := false
+	var lastErr error
+	for _, m := range c.members {
+		c.maybeRecover(m)
+		if !m.healthy {
+			continue
+		}
+		result, err := get(m)
+		if err != nil || isInvalid(result) {
+			c.demote(m)
+			lastErr = err
+			continue
+		}
+		weight := 1.0
+		if c.Policy == WeightedAverage && m.Residual > 0 {
+			weight = 1 / (m.Residual * m.Residual)
+		}
+		acc = sum(acc, result, weight)
+		weightSum += weight
+		have = true
+	}
+	if !have {
+		var zero T
+		if lastErr == nil {
+			lastErr = fmt.Errorf("estimator chain: no healthy estimator available")
+		}
+		return zero, lastErr
+	}
+	return scale(acc, 1/weightSum), nil
+}
+
+func sumFloat(acc, v []float64, weight float64) []float64 {
+	if acc == nil {
+		acc = make([]float64, len(v))
+	}
+	for i := range v {
+		if i < len(acc) {
+			acc[i] += v[i] * weight
+		}
+	}
+	return acc
+}
+
+func scaleFloat(v []float64, weight float64) []float64 {
+	out := make([]float64, len(v))
+	for i := range v {
+		out[i] = v[i] * weight
+	}
+	return out
+}
+
+func hasNaNFloat(v []float64) bool {
+	for _, f := range v {
+		if math.IsNaN(f) {
+			return true
+		}
+	}
+	return false
+}
+
+func sumComponents(acc, v []local.ComponentPower, weight float64) []local.ComponentPower {
+	if acc == nil {
+		acc = make([]local.ComponentPower, len(v))
+	}
+	for i := range v {
+		if i >= len(acc) {
+			break
+		}
+		acc[i].Core += v[i].Core * weight
+		acc[i].Dram += v[i].Dram * weight
+		acc[i].Uncore += v[i].Uncore * weight
+		acc[i].Pkg += v[i].Pkg * weight
+		acc[i].GPU += v[i].GPU * weight
+	}
+	return acc
+}
+
+func scaleComponents(v []local.ComponentPower, weight float64) []local.ComponentPower {
+	out := make([]local.ComponentPower, len(v))
+	for i, cp := range v {
+		out[i] = local.ComponentPower{
+			Core:   cp.Core * weight,
+			Dram:   cp.Dram * weight,
+			Uncore: cp.Uncore * weight,
+			Pkg:    cp.Pkg * weight,
+			GPU:    cp.GPU * weight,
+		}
+	}
+	return out
+}
+
+func hasNaNComponents(v []local.ComponentPower) bool {
+	for _, cp := range v {
+		if math.IsNaN(cp.Core) || math.IsNaN(cp.Dram) || math.IsNaN(cp.Uncore) || math.IsNaN(cp.Pkg) || math.IsNaN(cp.GPU) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultCooldown is used by NewEstimatorChainFromConfig when
+// config.EstimatorChainCooldown is zero or negative.
+const defaultCooldown = 30 * time.Second
+
+// Kind prefixes recognized in a config.EstimatorChainEndpoints entry; see
+// parseChainEndpoint.
+const (
+	modelServerEndpointKind = "modelserver"
+	staticURLEndpointKind   = "staticurl"
+)
+
+// parseChainEndpoint splits one config.EstimatorChainEndpoints entry into a
+// kind and a URL. An entry is either "modelserver=<url>" (POSTed to like a
+// Kepler Model Server) or "staticurl=<url>" (GET of a static weights JSON
+// file); an entry with no recognized "kind=" prefix is treated as
+// modelserver, matching a bare Model Server URL.
+func parseChainEndpoint(entry string) (kind, url string) {
+	if k, u, ok := strings.Cut(entry, "="); ok {
+		k = strings.TrimSpace(k)
+		if k == modelServerEndpointKind || k == staticURLEndpointKind {
+			return k, strings.TrimSpace(u)
+		}
+	}
+	return modelServerEndpointKind, strings.TrimSpace(entry)
+}
+
+// NewEstimatorChainFromConfig builds a chain from config.EstimatorChainEndpoints
+// (a comma-separated, ordered list of "modelserver=<url>" / "staticurl=<url>"
+// entries, see parseChainEndpoint), config.EstimatorChainPolicy and
+// config.EstimatorChainCooldown. Each entry becomes one modelType/outputType
+// estimator resolved against only the URL field its kind actually uses, so a
+// Model Server endpoint and a static initial-model URL can sit side by side
+// in the same chain without one masking the other; fallbacks, if any, are
+// appended after them (typically a single always-healthy static estimator).
+func NewEstimatorChainFromConfig(modelType types.EstimatorType, outputType types.ModelOutputType,
+	floatFeatureNames, systemMetaDataFeatureNames, systemMetaDataFeatureValues []string,
+	fallbacks ...EstimatorChainMember) *EstimatorChain {
+	var members []EstimatorChainMember
+	for _, entry := range strings.Split(config.EstimatorChainEndpoints, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kind, url := parseChainEndpoint(entry)
+		if url == "" {
+			continue
+		}
+		var modelServerEndpoint, modelWeightsURL string
+		if kind == staticURLEndpointKind {
+			modelWeightsURL = url
+		} else {
+			modelServerEndpoint = url
+		}
+		members = append(members, EstimatorChainMember{
+			Estimator: local.NewPowerEstimator(modelType, outputType, modelServerEndpoint, modelWeightsURL,
+				floatFeatureNames, systemMetaDataFeatureNames, systemMetaDataFeatureValues),
+		})
+	}
+	members = append(members, fallbacks...)
+
+	cooldown := config.EstimatorChainCooldown
+	if cooldown <= 0 {
+		cooldown = defaultCooldown
+	}
+	return NewEstimatorChain(HealthPolicy(config.EstimatorChainPolicy), cooldown, members...)
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/sustainable-computing-io/kepler/pkg/config"
+	"github.com/sustainable-computing-io/kepler/pkg/model/estimator/local"
+	"github.com/sustainable-computing-io/kepler/pkg/model/types"
+)
+
+// constantEstimator is a minimal local.PowerEstimator stand-in for a
+// static/ratio-based fallback: it ignores every sample and always reports
+// the same power.
+type constantEstimator struct {
+	platform  float64
+	component local.ComponentPower
+}
+
+func (c *constantEstimator) Start() error                          { return nil }
+func (c *constantEstimator) ResetSampleIdx()                       {}
+func (c *constantEstimator) AddNodeFeatureValues(_ []float64)      {}
+func (c *constantEstimator) AddContainerFeatureValues(_ []float64) {}
+func (c *constantEstimator) GetPlatformPower(_ bool) ([]float64, error) {
+	return []float64{c.platform}, nil
+}
+func (c *constantEstimator) GetComponentsPower(_ bool) ([]local.ComponentPower, error) {
+	return []local.ComponentPower{c.component}, nil
+}
+
+var _ local.PowerEstimator = (*constantEstimator)(nil)
+
+// chainTestWeights is a one-feature, bias-free linear model: prediction
+// equals the raw "cpu_cycles" feature value.
+var chainTestWeights = local.ModelWeights{
+	local.AllWeights{
+		NumericalVariables: map[string]local.NormalizedNumericalFeature{
+			"cpu_cycles": {Weight: 1, Mean: 0, Variance: 1},
+		},
+	},
+}
+
+// brokenUntilHealthyHandler serves chainTestWeights once *broken is false,
+// and a 500 while it is true, so a test can simulate a Model Server going
+// down and later recovering without changing its URL.
+func brokenUntilHealthyHandler(broken *bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if *broken {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(chainTestWeights)
+	}
+}
+
+var _ = Describe("Test Estimator Chain", func() {
+	BeforeEach(func() {
+		config.ModelServerEnable = true
+	})
+
+	It("falls through to a healthy fallback and recovers the primary once it comes back", func() {
+		broken := true
+		server := httptest.NewServer(brokenUntilHealthyHandler(&broken))
+		defer server.Close()
+
+		primary := &local.LinearRegressor{
+			ModelServerEndpoint: server.URL,
+			OutputType:          types.AbsModelWeight,
+			FloatFeatureNames:   []string{"cpu_cycles"},
+		}
+		fallback := &constantEstimator{platform: 9}
+
+		chain := NewEstimatorChain(FirstHealthy, 10*time.Millisecond,
+			EstimatorChainMember{Estimator: primary},
+			EstimatorChainMember{Estimator: fallback},
+		)
+
+		// primary.Start() fails against the broken server; the chain is
+		// still healthy overall because the fallback started fine.
+		Expect(chain.Start()).To(BeNil())
+
+		chain.ResetSampleIdx()
+		chain.AddNodeFeatureValues([]float64{4})
+		powers, err := chain.GetPlatformPower(false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(powers[0]).Should(BeEquivalentTo(9))
+
+		broken = false
+		time.Sleep(15 * time.Millisecond) // let the cooldown elapse
+
+		chain.ResetSampleIdx()
+		chain.AddNodeFeatureValues([]float64{4})
+		powers, err = chain.GetPlatformPower(false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(powers[0]).Should(BeEquivalentTo(4))
+	})
+
+	It("averages every healthy member under the Average policy", func() {
+		a := &constantEstimator{platform: 2}
+		b := &constantEstimator{platform: 4}
+		chain := NewEstimatorChain(Average, time.Second,
+			EstimatorChainMember{Estimator: a},
+			EstimatorChainMember{Estimator: b},
+		)
+		Expect(chain.Start()).To(BeNil())
+		chain.ResetSampleIdx()
+		chain.AddNodeFeatureValues([]float64{1})
+		powers, err := chain.GetPlatformPower(false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(powers[0]).Should(BeEquivalentTo(3))
+	})
+
+	It("weights members by the inverse square of their historical residual under WeightedAverage", func() {
+		precise := &constantEstimator{platform: 10} // low residual -> high weight
+		noisy := &constantEstimator{platform: 0}    // high residual -> low weight
+		chain := NewEstimatorChain(WeightedAverage, time.Second,
+			EstimatorChainMember{Estimator: precise, Residual: 1},
+			EstimatorChainMember{Estimator: noisy, Residual: 10},
+		)
+		Expect(chain.Start()).To(BeNil())
+		chain.ResetSampleIdx()
+		chain.AddNodeFeatureValues([]float64{1})
+		powers, err := chain.GetPlatformPower(false)
+		Expect(err).NotTo(HaveOccurred())
+		// weight(precise) = 1, weight(noisy) = 0.01 -> heavily tilted toward 10.
+		Expect(powers[0]).Should(BeNumerically(">", 9))
+	})
+
+	It("builds one estimator per config.EstimatorChainEndpoints entry, falling back to a healthy one", func() {
+		broken := true
+		server := httptest.NewServer(brokenUntilHealthyHandler(&broken))
+		defer server.Close()
+
+		config.EstimatorChainEndpoints = server.URL + ", "
+		config.EstimatorChainPolicy = "first-healthy"
+		config.EstimatorChainCooldown = time.Second
+		defer func() {
+			config.EstimatorChainEndpoints = ""
+			config.EstimatorChainPolicy = "first-healthy"
+			config.EstimatorChainCooldown = 30 * time.Second
+		}()
+
+		chain := NewEstimatorChainFromConfig(types.LinearRegressorType, types.AbsModelWeight,
+			[]string{"cpu_cycles"}, nil, nil,
+			EstimatorChainMember{Estimator: &constantEstimator{platform: 9}},
+		)
+
+		// primary.Start() fails against the broken server; the chain is
+		// still healthy overall because the fallback started fine.
+		Expect(chain.Start()).To(BeNil())
+		chain.ResetSampleIdx()
+		chain.AddNodeFeatureValues([]float64{4})
+		powers, err := chain.GetPlatformPower(false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(powers[0]).Should(BeEquivalentTo(9))
+	})
+
+	It("resolves a modelserver= entry and a staticurl= entry to distinct URLs, not a conflated pair", func() {
+		brokenModelServer := true
+		modelServer := httptest.NewServer(brokenUntilHealthyHandler(&brokenModelServer))
+		defer modelServer.Close()
+
+		staticURLBroken := false
+		staticServer := httptest.NewServer(brokenUntilHealthyHandler(&staticURLBroken))
+		defer staticServer.Close()
+
+		config.EstimatorChainEndpoints = "modelserver=" + modelServer.URL + ",staticurl=" + staticServer.URL
+		config.EstimatorChainPolicy = "first-healthy"
+		config.EstimatorChainCooldown = time.Second
+		defer func() {
+			config.EstimatorChainEndpoints = ""
+			config.EstimatorChainPolicy = "first-healthy"
+			config.EstimatorChainCooldown = 30 * time.Second
+		}()
+
+		chain := NewEstimatorChainFromConfig(types.LinearRegressorType, types.AbsModelWeight,
+			[]string{"cpu_cycles"}, nil, nil,
+		)
+
+		// the modelserver= entry fails to start against the broken server,
+		// but the staticurl= entry's GET isn't affected by it: had both
+		// entries resolved to the same ModelServerEndpoint/ModelWeightsURL
+		// pair, this would fail to start at all.
+		Expect(chain.Start()).To(BeNil())
+		chain.ResetSampleIdx()
+		chain.AddNodeFeatureValues([]float64{4})
+		powers, err := chain.GetPlatformPower(false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(powers[0]).Should(BeEquivalentTo(4))
+	})
+})
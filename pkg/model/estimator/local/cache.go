@@ -0,0 +1,186 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+cache.go
+fetchModelWeightsBytes fetches the raw model weights JSON for a request,
+either from the Kepler Model Server or a static ModelWeightsURL, and keeps a
+checksummed copy of it in config.ModelCacheDir. On subsequent calls the
+cached copy is served when the network fetch fails, when config.ModelCacheTTL
+has not yet elapsed, or when config.ModelCacheOffline is set, so that Start()
+keeps working without connectivity to the Model Server.
+*/
+
+package local
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sustainable-computing-io/kepler/pkg/config"
+)
+
+// cacheMeta is stored alongside the cached weights so a later fetch can
+// send an If-None-Match request and know when the cache was last refreshed.
+type cacheMeta struct {
+	ETag      string    `json:"ETag"`
+	FetchedAt time.Time `json:"FetchedAt"`
+}
+
+// cacheKey is a stable identifier for the {OutputType, ModelWeightsURL,
+// ModelServerEndpoint} tuple a set of weights was fetched for.
+func cacheKey(outputType, modelWeightsURL, modelServerEndpoint string) string {
+	sum := sha256.Sum256([]byte(outputType + "|" + modelWeightsURL + "|" + modelServerEndpoint))
+	return hex.EncodeToString(sum[:])
+}
+
+func cachePaths(dir, key string) (dataPath, sumPath, metaPath string) {
+	return filepath.Join(dir, key+".json"),
+		filepath.Join(dir, key+".sha256"),
+		filepath.Join(dir, key+".meta.json")
+}
+
+// readCache loads and verifies a previously cached copy of the weights.
+func readCache(dir, key string) ([]byte, cacheMeta, error) {
+	dataPath, sumPath, metaPath := cachePaths(dir, key)
+	data, err := os.ReadFile(dataPath)
+	if err != nil {
+		return nil, cacheMeta{}, err
+	}
+	wantSum, err := os.ReadFile(sumPath)
+	if err != nil {
+		return nil, cacheMeta{}, err
+	}
+	gotSum := sha256.Sum256(data)
+	if hex.EncodeToString(gotSum[:]) != strings.TrimSpace(string(wantSum)) {
+		return nil, cacheMeta{}, fmt.Errorf("model cache checksum mismatch for %s", dataPath)
+	}
+	var meta cacheMeta
+	if metaBytes, err := os.ReadFile(metaPath); err == nil {
+		_ = json.Unmarshal(metaBytes, &meta)
+	}
+	return data, meta, nil
+}
+
+// writeCache stores data plus its SHA-256 checksum and meta.
+func writeCache(dir, key string, data []byte, meta cacheMeta) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	dataPath, sumPath, metaPath := cachePaths(dir, key)
+	if err := os.WriteFile(dataPath, data, 0o644); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	if err := os.WriteFile(sumPath, []byte(hex.EncodeToString(sum[:])), 0o644); err != nil {
+		return err
+	}
+	metaBytes, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(metaPath, metaBytes, 0o644)
+}
+
+// fetchModelWeightsBytes returns the raw weights JSON for req. It serves the
+// on-disk cache without a network fetch while the cached copy is younger than
+// ModelCacheTTL or when ModelCacheOffline is set; otherwise it fetches from
+// the network and falls back to the cached copy if that fetch fails.
+func fetchModelWeightsBytes(modelServerEndpoint, modelWeightsURL string, req ModelRequest) ([]byte, error) {
+	dir := config.ModelCacheDir
+	key := cacheKey(req.OutputType, modelWeightsURL, modelServerEndpoint)
+
+	cached, meta, cacheErr := readCache(dir, key)
+	cacheFresh := cacheErr == nil && time.Since(meta.FetchedAt) < config.ModelCacheTTL
+
+	if config.ModelCacheOffline || cacheFresh {
+		if cacheErr == nil {
+			return cached, nil
+		}
+		if config.ModelCacheOffline {
+			return nil, fmt.Errorf("model cache offline mode: %w", cacheErr)
+		}
+	}
+
+	data, etag, notModified, fetchErr := fetchLive(modelServerEndpoint, modelWeightsURL, req, meta.ETag)
+	if fetchErr != nil {
+		if cacheErr == nil {
+			return cached, nil
+		}
+		return nil, fetchErr
+	}
+	if notModified {
+		_ = writeCache(dir, key, cached, cacheMeta{ETag: meta.ETag, FetchedAt: time.Now()})
+		return cached, nil
+	}
+	_ = writeCache(dir, key, data, cacheMeta{ETag: etag, FetchedAt: time.Now()})
+	return data, nil
+}
+
+// fetchLive performs the actual HTTP round trip: a POST of req to
+// modelServerEndpoint when the Model Server is enabled, otherwise a GET of
+// modelWeightsURL. ifNoneMatch, when set, is sent so the server can reply
+// 304 Not Modified instead of resending an unchanged body.
+func fetchLive(modelServerEndpoint, modelWeightsURL string, req ModelRequest, ifNoneMatch string) (data []byte, etag string, notModified bool, err error) {
+	var httpReq *http.Request
+	if config.ModelServerEnable && modelServerEndpoint != "" {
+		body, merr := json.Marshal(req)
+		if merr != nil {
+			return nil, "", false, merr
+		}
+		httpReq, err = http.NewRequest(http.MethodPost, modelServerEndpoint, bytes.NewReader(body))
+		if err == nil {
+			httpReq.Header.Set("Content-Type", "application/json")
+		}
+	} else if modelWeightsURL != "" {
+		httpReq, err = http.NewRequest(http.MethodGet, modelWeightsURL, nil)
+	} else {
+		return nil, "", false, fmt.Errorf("no Model Server endpoint or ModelWeightsURL configured")
+	}
+	if err != nil {
+		return nil, "", false, err
+	}
+	if ifNoneMatch != "" {
+		httpReq.Header.Set("If-None-Match", ifNoneMatch)
+	}
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, "", false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, ifNoneMatch, true, nil
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, "", false, fmt.Errorf("model weights fetch failed: %s", resp.Status)
+	}
+	data, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", false, err
+	}
+	return data, resp.Header.Get("ETag"), false, nil
+}
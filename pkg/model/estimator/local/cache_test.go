@@ -0,0 +1,86 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/sustainable-computing-io/kepler/pkg/config"
+	"github.com/sustainable-computing-io/kepler/pkg/model/types"
+)
+
+var _ = Describe("Test Model Weights Cache", func() {
+	var origCacheDir string
+	var origTTL = config.ModelCacheTTL
+
+	BeforeEach(func() {
+		origCacheDir = config.ModelCacheDir
+		config.ModelCacheDir = GinkgoT().TempDir()
+		config.ModelCacheTTL = origTTL
+		config.ModelCacheOffline = false
+	})
+
+	AfterEach(func() {
+		config.ModelCacheDir = origCacheDir
+		config.ModelCacheOffline = false
+	})
+
+	It("serves the warm cache once the Model Server becomes unreachable", func() {
+		testServer := httptest.NewServer(http.HandlerFunc(getDummyWeights))
+
+		warm := genLinearRegressor(types.AbsModelWeight, testServer.URL, "")
+		Expect(warm.Start()).To(BeNil())
+		warm.ResetSampleIdx()
+		warm.AddNodeFeatureValues(nodeFeatureValues)
+		onlinePowers, err := warm.GetPlatformPower(false)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Make the Model Server unreachable without changing its URL, so the
+		// cache key (which is derived from the endpoint) still matches.
+		testServer.Close()
+
+		cold := genLinearRegressor(types.AbsModelWeight, testServer.URL, "")
+		Expect(cold.Start()).To(BeNil())
+		cold.ResetSampleIdx()
+		cold.AddNodeFeatureValues(nodeFeatureValues)
+		cachedPowers, err := cold.GetPlatformPower(false)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(cachedPowers).To(Equal(onlinePowers))
+	})
+
+	It("serves the cache without touching the network when ModelCacheOffline is set", func() {
+		testServer := httptest.NewServer(http.HandlerFunc(getDummyWeights))
+		defer testServer.Close()
+
+		warm := genLinearRegressor(types.AbsComponentModelWeight, testServer.URL, "ComponentModelWeight")
+		Expect(warm.Start()).To(BeNil())
+
+		config.ModelCacheOffline = true
+		offline := genLinearRegressor(types.AbsComponentModelWeight, testServer.URL, "ComponentModelWeight")
+		Expect(offline.Start()).To(BeNil())
+		offline.ResetSampleIdx()
+		offline.AddNodeFeatureValues(nodeFeatureValues)
+		compPowers, err := offline.GetComponentsPower(false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(compPowers[0].Core).Should(BeEquivalentTo(4000))
+	})
+})
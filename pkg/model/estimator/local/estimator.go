@@ -0,0 +1,262 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+estimator.go
+PowerEstimator is the common interface implemented by every local (in-process)
+power estimation backend: LinearRegressor, NeuralNetRegressor and
+GradientBoostingTreeRegressor. All of them request weights with the same
+FloatFeatureNames and SystemMetaDataFeatureNames and are fed the same
+per-sample numerical feature values. LinearRegressor normalizes those values
+and adds one-hot categorical weights; NeuralNetRegressor and
+GradientBoostingTreeRegressor use the raw values and no categorical features.
+*/
+
+package local
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/sustainable-computing-io/kepler/pkg/model/types"
+)
+
+// PowerEstimator is implemented by every local power estimation backend.
+type PowerEstimator interface {
+	// Start fetches (and validates) the model weights, either from the
+	// Kepler Model Server or from a static ModelWeightsURL.
+	Start() error
+	// AddNodeFeatureValues appends one node-level sample to estimate.
+	AddNodeFeatureValues(values []float64)
+	// AddContainerFeatureValues appends one container-level sample to estimate.
+	AddContainerFeatureValues(values []float64)
+	// GetPlatformPower returns one platform power estimate (in Watts) per
+	// sample added since the last ResetSampleIdx.
+	GetPlatformPower(isIdlePower bool) ([]float64, error)
+	// GetComponentsPower returns one per-component power estimate (in
+	// milliWatts) per sample added since the last ResetSampleIdx.
+	GetComponentsPower(isIdlePower bool) ([]ComponentPower, error)
+	// ResetSampleIdx discards all samples added so far.
+	ResetSampleIdx()
+}
+
+// CategoricalFeature is the learned weight of a single one-hot category
+// value, e.g. the "Sandy Bridge" value of the "cpu_architecture" feature.
+type CategoricalFeature struct {
+	Weight float64
+}
+
+// NormalizedNumericalFeature is the learned weight of a numerical feature
+// together with the mean/variance used to normalize raw samples before the
+// weight is applied.
+type NormalizedNumericalFeature struct {
+	Weight   float64
+	Mean     float64
+	Variance float64
+}
+
+// AllWeights is a single trained linear model: a bias, a weight per
+// categorical feature value and a weight (plus normalization) per numerical
+// feature.
+type AllWeights struct {
+	BiasWeight           float64                                  `json:"Bias_Weight"`
+	CategoricalVariables map[string]map[string]CategoricalFeature `json:"Categorical_Variables"`
+	NumericalVariables   map[string]NormalizedNumericalFeature    `json:"Numerical_Variables"`
+	// Residuals is optional; when absent, predictions from this model
+	// carry zero uncertainty (the pre-existing behavior).
+	Residuals *Residuals `json:"Residuals,omitempty"`
+}
+
+// Residuals describes the training-time residual error of a model, used to
+// turn a point prediction into a predictive variance.
+type Residuals struct {
+	ResidualStdDev    float64     `json:"ResidualStdDev"`
+	FeatureCovariance [][]float64 `json:"FeatureCovariance"`
+}
+
+// ModelWeights is the weights for one power output (platform or a single
+// component). It is a slice so that a future trainer revision can hand back
+// more than one candidate model without changing the wire format; today
+// only the first entry is used.
+type ModelWeights []AllWeights
+
+// ComponentModelWeights holds one ModelWeights per RAPL component
+// (e.g. "core", "dram", "uncore", "pkg").
+type ComponentModelWeights map[string]ModelWeights
+
+// ComponentPower is one sample's estimated power per RAPL component, in
+// milliWatts.
+type ComponentPower struct {
+	Core   float64
+	Dram   float64
+	Uncore float64
+	Pkg    float64
+	GPU    float64
+}
+
+// ModelRequest is the body POSTed to the Kepler Model Server to ask for a
+// set of trained weights.
+type ModelRequest struct {
+	FloatFeatureNames           []string `json:"FloatFeatureNames"`
+	SystemMetaDataFeatureNames  []string `json:"SystemMetaDataFeatureNames"`
+	SystemMetaDataFeatureValues []string `json:"SystemMetaDataFeatureValues"`
+	OutputType                  string   `json:"OutputType"`
+	TrainerName                 string   `json:"TrainerName,omitempty"`
+}
+
+// platformToComponentScale converts a platform-power-style prediction
+// (Watts) into the milliWatt units GetComponentsPower reports.
+const platformToComponentScale = 1000
+
+// fetchModelWeights fills in into with the weights for req, decoding either
+// from the Model Server (POST req to modelServerEndpoint) when
+// config.ModelServerEnable is set and an endpoint is configured, or
+// otherwise by GETting modelWeightsURL. A copy of the raw response is kept
+// in config.ModelCacheDir; see cache.go for the on-disk caching and offline
+// fallback behavior.
+func fetchModelWeights(modelServerEndpoint, modelWeightsURL string, req ModelRequest, into interface{}) error {
+	data, err := fetchModelWeightsBytes(modelServerEndpoint, modelWeightsURL, req)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, into)
+}
+
+// normalize scales a raw feature value by the mean/variance learned at
+// training time.
+func normalize(value, mean, variance float64) float64 {
+	if variance == 0 {
+		return 0
+	}
+	return (value - mean) / math.Sqrt(variance)
+}
+
+// predictLinear evaluates a single AllWeights model against one sample's
+// numerical feature values plus the (fixed, sample-independent) categorical
+// feature values.
+func predictLinear(w AllWeights, floatFeatureNames []string, values []float64, systemMetaDataFeatureNames, systemMetaDataFeatureValues []string) float64 {
+	pred := w.BiasWeight
+	for i, name := range systemMetaDataFeatureNames {
+		catMap, ok := w.CategoricalVariables[name]
+		if !ok {
+			continue
+		}
+		if cf, ok := catMap[systemMetaDataFeatureValues[i]]; ok {
+			pred += cf.Weight
+		}
+	}
+	for i, name := range floatFeatureNames {
+		nf, ok := w.NumericalVariables[name]
+		if !ok {
+			continue
+		}
+		pred += nf.Weight * normalize(values[i], nf.Mean, nf.Variance)
+	}
+	return pred
+}
+
+// normalizedFeatureVector builds the same normalized numerical feature
+// vector predictLinear sums over, in floatFeatureNames order, skipping any
+// feature w has no NumericalVariables entry for. predictUncertainty needs
+// this vector to line up one-for-one with w.Residuals.FeatureCovariance.
+func normalizedFeatureVector(w AllWeights, floatFeatureNames []string, values []float64) []float64 {
+	var x []float64
+	for i, name := range floatFeatureNames {
+		if nf, ok := w.NumericalVariables[name]; ok {
+			x = append(x, normalize(values[i], nf.Mean, nf.Variance))
+		}
+	}
+	return x
+}
+
+// predictUncertainty returns the standard deviation of predictLinear's
+// point prediction for w: sqrt(residualStdDev^2 + x^T * FeatureCovariance * x).
+// A missing Residuals block (old model weights) yields zero uncertainty.
+func predictUncertainty(w AllWeights, floatFeatureNames []string, values []float64) float64 {
+	if w.Residuals == nil {
+		return 0
+	}
+	variance := w.Residuals.ResidualStdDev * w.Residuals.ResidualStdDev
+	x := normalizedFeatureVector(w, floatFeatureNames, values)
+	cov := w.Residuals.FeatureCovariance
+	if len(cov) == len(x) {
+		for i := range x {
+			if len(cov[i]) != len(x) {
+				continue
+			}
+			for j := range x {
+				variance += x[i] * cov[i][j] * x[j]
+			}
+		}
+	}
+	if variance < 0 {
+		return 0
+	}
+	return math.Sqrt(variance)
+}
+
+// setComponentPower assigns a predicted value to the named RAPL component
+// field of cp.
+func setComponentPower(cp *ComponentPower, component string, value float64) {
+	switch component {
+	case "core":
+		cp.Core = value
+	case "dram":
+		cp.Dram = value
+	case "uncore":
+		cp.Uncore = value
+	case "pkg":
+		cp.Pkg = value
+	case "gpu":
+		cp.GPU = value
+	}
+}
+
+// NewPowerEstimator builds the configured PowerEstimator backend. Callers
+// that don't care about the backend (most production call sites) should
+// pass config.EstimatorModel as modelType.
+func NewPowerEstimator(modelType types.EstimatorType, outputType types.ModelOutputType, modelServerEndpoint, modelWeightsURL string,
+	floatFeatureNames, systemMetaDataFeatureNames, systemMetaDataFeatureValues []string) PowerEstimator {
+	switch modelType {
+	case types.NeuralNetworkType:
+		return &NeuralNetRegressor{
+			ModelServerEndpoint:         modelServerEndpoint,
+			OutputType:                  outputType,
+			FloatFeatureNames:           floatFeatureNames,
+			SystemMetaDataFeatureNames:  systemMetaDataFeatureNames,
+			SystemMetaDataFeatureValues: systemMetaDataFeatureValues,
+			ModelWeightsURL:             modelWeightsURL,
+		}
+	case types.GradientBoostingTreeType:
+		return &GradientBoostingTreeRegressor{
+			ModelServerEndpoint:         modelServerEndpoint,
+			OutputType:                  outputType,
+			FloatFeatureNames:           floatFeatureNames,
+			SystemMetaDataFeatureNames:  systemMetaDataFeatureNames,
+			SystemMetaDataFeatureValues: systemMetaDataFeatureValues,
+			ModelWeightsURL:             modelWeightsURL,
+		}
+	default:
+		return &LinearRegressor{
+			ModelServerEndpoint:         modelServerEndpoint,
+			OutputType:                  outputType,
+			FloatFeatureNames:           floatFeatureNames,
+			SystemMetaDataFeatureNames:  systemMetaDataFeatureNames,
+			SystemMetaDataFeatureValues: systemMetaDataFeatureValues,
+			ModelWeightsURL:             modelWeightsURL,
+		}
+	}
+}
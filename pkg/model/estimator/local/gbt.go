@@ -0,0 +1,166 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+gbt.go
+estimate (node/pod) component and total power with a gradient-boosted
+ensemble of regression trees when trained weights are available. Each tree
+is a flat array of nodes; a node is a leaf when it has no children.
+*/
+
+package local
+
+import (
+	"fmt"
+
+	"github.com/sustainable-computing-io/kepler/pkg/model/types"
+)
+
+// GBTNode is one node of a flattened binary regression tree. Left and
+// Right are indices into the owning GBTTree's Nodes slice; a node with no
+// children (Left < 0 and Right < 0) is a leaf and contributes LeafValue.
+type GBTNode struct {
+	FeatureIdx int     `json:"FeatureIdx"`
+	Threshold  float64 `json:"Threshold"`
+	Left       int     `json:"Left"`
+	Right      int     `json:"Right"`
+	LeafValue  float64 `json:"LeafValue"`
+}
+
+// GBTTree is one regression tree in the ensemble.
+type GBTTree struct {
+	Nodes []GBTNode `json:"Nodes"`
+}
+
+// GBTWeights is a trained gradient-boosted ensemble: a base score plus a
+// learning-rate-scaled sum of tree predictions.
+type GBTWeights struct {
+	Trees        []GBTTree `json:"Trees"`
+	LearningRate float64   `json:"LearningRate"`
+	BaseScore    float64   `json:"BaseScore"`
+}
+
+// GradientBoostingTreeRegressor is the gradient-boosted-tree PowerEstimator.
+type GradientBoostingTreeRegressor struct {
+	ModelServerEndpoint         string
+	OutputType                  types.ModelOutputType
+	FloatFeatureNames           []string
+	SystemMetaDataFeatureNames  []string
+	SystemMetaDataFeatureValues []string
+	ModelWeightsURL             string
+
+	modelWeights          GBTWeights
+	componentModelWeights map[string]GBTWeights
+	sampleValues          [][]float64
+}
+
+var _ PowerEstimator = (*GradientBoostingTreeRegressor)(nil)
+
+func (r *GradientBoostingTreeRegressor) isComponentOutput() bool {
+	return r.OutputType == types.AbsComponentModelWeight || r.OutputType == types.DynComponentModelWeight
+}
+
+// Start fetches the trained weights for r.OutputType, either from the
+// Kepler Model Server (when config.ModelServerEnable is set and
+// ModelServerEndpoint is non-empty) or from ModelWeightsURL.
+func (r *GradientBoostingTreeRegressor) Start() error {
+	req := ModelRequest{
+		FloatFeatureNames:           r.FloatFeatureNames,
+		SystemMetaDataFeatureNames:  r.SystemMetaDataFeatureNames,
+		SystemMetaDataFeatureValues: r.SystemMetaDataFeatureValues,
+		OutputType:                  r.OutputType.String(),
+	}
+	if r.isComponentOutput() {
+		return fetchModelWeights(r.ModelServerEndpoint, r.ModelWeightsURL, req, &r.componentModelWeights)
+	}
+	return fetchModelWeights(r.ModelServerEndpoint, r.ModelWeightsURL, req, &r.modelWeights)
+}
+
+// ResetSampleIdx discards all samples added so far.
+func (r *GradientBoostingTreeRegressor) ResetSampleIdx() {
+	r.sampleValues = nil
+}
+
+// AddNodeFeatureValues appends one node-level sample to estimate.
+func (r *GradientBoostingTreeRegressor) AddNodeFeatureValues(values []float64) {
+	r.sampleValues = append(r.sampleValues, values)
+}
+
+// AddContainerFeatureValues appends one container-level sample to estimate.
+func (r *GradientBoostingTreeRegressor) AddContainerFeatureValues(values []float64) {
+	r.sampleValues = append(r.sampleValues, values)
+}
+
+// GetPlatformPower returns one platform power estimate (in Watts) per
+// sample added since the last ResetSampleIdx.
+func (r *GradientBoostingTreeRegressor) GetPlatformPower(isIdlePower bool) ([]float64, error) {
+	if len(r.modelWeights.Trees) == 0 {
+		return nil, fmt.Errorf("no platform power model weights loaded")
+	}
+	powers := make([]float64, len(r.sampleValues))
+	for i, values := range r.sampleValues {
+		powers[i] = evalGBT(r.modelWeights, values)
+	}
+	return powers, nil
+}
+
+// GetComponentsPower returns one per-component power estimate (in
+// milliWatts) per sample added since the last ResetSampleIdx.
+func (r *GradientBoostingTreeRegressor) GetComponentsPower(isIdlePower bool) ([]ComponentPower, error) {
+	if len(r.componentModelWeights) == 0 {
+		return nil, fmt.Errorf("no component power model weights loaded")
+	}
+	powers := make([]ComponentPower, len(r.sampleValues))
+	for i, values := range r.sampleValues {
+		var cp ComponentPower
+		for component, weights := range r.componentModelWeights {
+			if len(weights.Trees) == 0 {
+				continue
+			}
+			setComponentPower(&cp, component, evalGBT(weights, values)*platformToComponentScale)
+		}
+		powers[i] = cp
+	}
+	return powers, nil
+}
+
+// evalGBT sums the learning-rate-scaled prediction of every tree in w on
+// top of the base score.
+func evalGBT(w GBTWeights, values []float64) float64 {
+	pred := w.BaseScore
+	for _, t := range w.Trees {
+		pred += w.LearningRate * evalTree(t, values)
+	}
+	return pred
+}
+
+// evalTree walks t from the root until it reaches a leaf, following Left
+// when the sample's FeatureIdx value is <= Threshold and Right otherwise.
+func evalTree(t GBTTree, values []float64) float64 {
+	idx := 0
+	for idx >= 0 && idx < len(t.Nodes) {
+		n := t.Nodes[idx]
+		if n.Left < 0 && n.Right < 0 {
+			return n.LeafValue
+		}
+		if n.FeatureIdx >= 0 && n.FeatureIdx < len(values) && values[n.FeatureIdx] <= n.Threshold {
+			idx = n.Left
+		} else {
+			idx = n.Right
+		}
+	}
+	return 0
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/sustainable-computing-io/kepler/pkg/config"
+	"github.com/sustainable-computing-io/kepler/pkg/model/types"
+)
+
+var (
+	SampleGBTWeights = GBTWeights{
+		Trees: []GBTTree{
+			{
+				Nodes: []GBTNode{
+					{FeatureIdx: 0, Threshold: 1.5, Left: 1, Right: 2},
+					{Left: -1, Right: -1, LeafValue: 1},
+					{Left: -1, Right: -1, LeafValue: 3},
+				},
+			},
+		},
+		LearningRate: 1,
+		BaseScore:    0,
+	}
+	SampleComponentGBTWeightResponse = map[string]GBTWeights{
+		"core": SampleGBTWeights,
+		"dram": SampleGBTWeights,
+	}
+)
+
+func getDummyGBTWeights(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		panic(err)
+	}
+	var req ModelRequest
+	if err := json.Unmarshal(reqBody, &req); err != nil {
+		panic(err)
+	}
+	if strings.Contains(req.OutputType, "ComponentModelWeight") {
+		err = json.NewEncoder(w).Encode(SampleComponentGBTWeightResponse)
+	} else {
+		err = json.NewEncoder(w).Encode(SampleGBTWeights)
+	}
+	if err != nil {
+		panic(err)
+	}
+}
+
+func genGBTRegressor(outputType types.ModelOutputType, modelServerEndpoint string) GradientBoostingTreeRegressor {
+	config.ModelServerEnable = true
+	config.ModelServerEndpoint = modelServerEndpoint
+	return GradientBoostingTreeRegressor{
+		ModelServerEndpoint:         modelServerEndpoint,
+		OutputType:                  outputType,
+		FloatFeatureNames:           containerFeatureNames,
+		SystemMetaDataFeatureNames:  systemMetaDataFeatureNames,
+		SystemMetaDataFeatureValues: systemMetaDataFeatureValues,
+	}
+}
+
+var _ = Describe("Test GBT Weight Unit", func() {
+	Context("with dummy model server", func() {
+		It("Get Node Platform Power By Gradient Boosting Trees with ModelServerEndpoint", func() {
+			testServer := httptest.NewServer(http.HandlerFunc(getDummyGBTWeights))
+			r := genGBTRegressor(types.AbsModelWeight, testServer.URL)
+			Expect(r.Start()).To(BeNil())
+			r.ResetSampleIdx()
+			r.AddNodeFeatureValues(nodeFeatureValues)
+			powers, err := r.GetPlatformPower(false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(powers)).Should(Equal(1))
+			Expect(powers[0]).Should(BeEquivalentTo(3))
+		})
+
+		It("Get Node Components Power By Gradient Boosting Trees with ModelServerEndpoint", func() {
+			testServer := httptest.NewServer(http.HandlerFunc(getDummyGBTWeights))
+			r := genGBTRegressor(types.AbsComponentModelWeight, testServer.URL)
+			Expect(r.Start()).To(BeNil())
+			r.ResetSampleIdx()
+			r.AddNodeFeatureValues(nodeFeatureValues)
+			compPowers, err := r.GetComponentsPower(false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(compPowers)).Should(Equal(1))
+			Expect(compPowers[0].Core).Should(BeEquivalentTo(3000))
+		})
+
+		It("Get Container Platform Power By Gradient Boosting Trees with ModelServerEndpoint", func() {
+			testServer := httptest.NewServer(http.HandlerFunc(getDummyGBTWeights))
+			r := genGBTRegressor(types.DynModelWeight, testServer.URL)
+			Expect(r.Start()).To(BeNil())
+			r.ResetSampleIdx()
+			for _, values := range containerFeatureValues {
+				r.AddContainerFeatureValues(values)
+			}
+			powers, err := r.GetPlatformPower(false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(powers)).Should(Equal(len(containerFeatureValues)))
+			Expect(powers[0]).Should(BeEquivalentTo(1))
+		})
+
+		It("Get Container Components Power By Gradient Boosting Trees with ModelServerEndpoint", func() {
+			testServer := httptest.NewServer(http.HandlerFunc(getDummyGBTWeights))
+			r := genGBTRegressor(types.DynComponentModelWeight, testServer.URL)
+			Expect(r.Start()).To(BeNil())
+			r.ResetSampleIdx()
+			for _, values := range containerFeatureValues {
+				r.AddContainerFeatureValues(values)
+			}
+			compPowers, err := r.GetComponentsPower(false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(compPowers)).Should(Equal(len(containerFeatureValues)))
+			Expect(compPowers[0].Core).Should(BeEquivalentTo(1000))
+		})
+	})
+})
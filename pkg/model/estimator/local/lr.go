@@ -0,0 +1,144 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+lr.go
+estimate (node/pod) component and total power by linear regression approach when trained model weights are available.
+The model weights can be obtained by Kepler Model Server or configured initial model URL.
+*/
+
+package local
+
+import (
+	"fmt"
+
+	"github.com/sustainable-computing-io/kepler/pkg/model/types"
+)
+
+// LinearRegressor is the bias + weighted-sum PowerEstimator: a single
+// AllWeights per output (platform power) or per RAPL component.
+type LinearRegressor struct {
+	ModelServerEndpoint         string
+	OutputType                  types.ModelOutputType
+	FloatFeatureNames           []string
+	SystemMetaDataFeatureNames  []string
+	SystemMetaDataFeatureValues []string
+	ModelWeightsURL             string
+
+	modelWeights          ModelWeights
+	componentModelWeights ComponentModelWeights
+	sampleValues          [][]float64
+}
+
+var _ PowerEstimator = (*LinearRegressor)(nil)
+
+func (r *LinearRegressor) isComponentOutput() bool {
+	return r.OutputType == types.AbsComponentModelWeight || r.OutputType == types.DynComponentModelWeight
+}
+
+// Start fetches the trained weights for r.OutputType, either from the
+// Kepler Model Server (when config.ModelServerEnable is set and
+// ModelServerEndpoint is non-empty) or from ModelWeightsURL.
+func (r *LinearRegressor) Start() error {
+	req := ModelRequest{
+		FloatFeatureNames:           r.FloatFeatureNames,
+		SystemMetaDataFeatureNames:  r.SystemMetaDataFeatureNames,
+		SystemMetaDataFeatureValues: r.SystemMetaDataFeatureValues,
+		OutputType:                  r.OutputType.String(),
+	}
+	if r.isComponentOutput() {
+		return fetchModelWeights(r.ModelServerEndpoint, r.ModelWeightsURL, req, &r.componentModelWeights)
+	}
+	return fetchModelWeights(r.ModelServerEndpoint, r.ModelWeightsURL, req, &r.modelWeights)
+}
+
+// ResetSampleIdx discards all samples added so far.
+func (r *LinearRegressor) ResetSampleIdx() {
+	r.sampleValues = nil
+}
+
+// AddNodeFeatureValues appends one node-level sample to estimate.
+func (r *LinearRegressor) AddNodeFeatureValues(values []float64) {
+	r.sampleValues = append(r.sampleValues, values)
+}
+
+// AddContainerFeatureValues appends one container-level sample to estimate.
+func (r *LinearRegressor) AddContainerFeatureValues(values []float64) {
+	r.sampleValues = append(r.sampleValues, values)
+}
+
+// GetPlatformPower returns one platform power estimate (in Watts) per
+// sample added since the last ResetSampleIdx. It discards the uncertainty
+// from GetPlatformPowerWithUncertainty; callers that want it should call
+// that instead.
+func (r *LinearRegressor) GetPlatformPower(isIdlePower bool) ([]float64, error) {
+	powers, _, err := r.GetPlatformPowerWithUncertainty(isIdlePower)
+	return powers, err
+}
+
+// GetPlatformPowerWithUncertainty returns one platform power estimate (in
+// Watts) per sample added since the last ResetSampleIdx, plus its standard
+// deviation (0 when the loaded weights have no Residuals block).
+func (r *LinearRegressor) GetPlatformPowerWithUncertainty(isIdlePower bool) ([]float64, []float64, error) {
+	if len(r.modelWeights) == 0 {
+		return nil, nil, fmt.Errorf("no platform power model weights loaded")
+	}
+	powers := make([]float64, len(r.sampleValues))
+	uncertainties := make([]float64, len(r.sampleValues))
+	for i, values := range r.sampleValues {
+		w := r.modelWeights[0]
+		powers[i] = predictLinear(w, r.FloatFeatureNames, values, r.SystemMetaDataFeatureNames, r.SystemMetaDataFeatureValues)
+		uncertainties[i] = predictUncertainty(w, r.FloatFeatureNames, values)
+	}
+	return powers, uncertainties, nil
+}
+
+// GetComponentsPower returns one per-component power estimate (in
+// milliWatts) per sample added since the last ResetSampleIdx. It discards
+// the uncertainty from GetComponentsPowerWithUncertainty; callers that want
+// it should call that instead.
+func (r *LinearRegressor) GetComponentsPower(isIdlePower bool) ([]ComponentPower, error) {
+	powers, _, err := r.GetComponentsPowerWithUncertainty(isIdlePower)
+	return powers, err
+}
+
+// GetComponentsPowerWithUncertainty returns one per-component power
+// estimate (in milliWatts) per sample added since the last ResetSampleIdx,
+// plus its standard deviation (0 per component whose weights have no
+// Residuals block).
+func (r *LinearRegressor) GetComponentsPowerWithUncertainty(isIdlePower bool) ([]ComponentPower, []ComponentPower, error) {
+	if len(r.componentModelWeights) == 0 {
+		return nil, nil, fmt.Errorf("no component power model weights loaded")
+	}
+	powers := make([]ComponentPower, len(r.sampleValues))
+	uncertainties := make([]ComponentPower, len(r.sampleValues))
+	for i, values := range r.sampleValues {
+		var cp, cu ComponentPower
+		for component, weights := range r.componentModelWeights {
+			if len(weights) == 0 {
+				continue
+			}
+			w := weights[0]
+			pred := predictLinear(w, r.FloatFeatureNames, values, r.SystemMetaDataFeatureNames, r.SystemMetaDataFeatureValues)
+			uncertainty := predictUncertainty(w, r.FloatFeatureNames, values)
+			setComponentPower(&cp, component, pred*platformToComponentScale)
+			setComponentPower(&cu, component, uncertainty*platformToComponentScale)
+		}
+		powers[i] = cp
+		uncertainties[i] = cu
+	}
+	return powers, uncertainties, nil
+}
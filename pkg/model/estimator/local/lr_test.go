@@ -91,6 +91,10 @@ func genWeights(numericalVars map[string]NormalizedNumericalFeature) ModelWeight
 			BiasWeight:           1.0,
 			CategoricalVariables: map[string]map[string]CategoricalFeature{"cpu_architecture": SampleCategoricalFeatures},
 			NumericalVariables:   numericalVars,
+			Residuals: &Residuals{
+				ResidualStdDev:    1.0,
+				FeatureCovariance: [][]float64{{1.0}},
+			},
 		},
 	}
 }
@@ -189,6 +193,44 @@ var _ = Describe("Test LR Weight Unit", func() {
 			// TODO: verify if the power makes sense
 			Expect(compPowers[0].Core).Should(BeEquivalentTo(3000))
 		})
+
+		It("Get Node Platform Power Uncertainty By Linear Regression with ModelServerEndpoint", func() {
+			testServer := httptest.NewServer(http.HandlerFunc(getDummyWeights))
+			r := genLinearRegressor(types.AbsModelWeight, testServer.URL, "")
+			Expect(r.Start()).To(BeNil())
+			r.ResetSampleIdx()
+			r.AddNodeFeatureValues(nodeFeatureValues)
+			powers, uncertainties, err := r.GetPlatformPowerWithUncertainty(false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(powers[0]).Should(BeEquivalentTo(4))
+			Expect(uncertainties[0]).Should(BeNumerically(">", 0))
+		})
+
+		It("Get Container Platform Power Uncertainty By Linear Regression with ModelServerEndpoint", func() {
+			testServer := httptest.NewServer(http.HandlerFunc(getDummyWeights))
+			r := genLinearRegressor(types.DynModelWeight, testServer.URL, "")
+			Expect(r.Start()).To(BeNil())
+			r.ResetSampleIdx()
+			for _, containerFeatureValues := range containerFeatureValues {
+				r.AddContainerFeatureValues(containerFeatureValues)
+			}
+			powers, uncertainties, err := r.GetPlatformPowerWithUncertainty(false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(powers[0]).Should(BeEquivalentTo(3))
+			Expect(uncertainties[0]).Should(BeNumerically(">", 0))
+		})
+
+		It("Get Node Components Power Uncertainty By Linear Regression with ModelServerEndpoint", func() {
+			testServer := httptest.NewServer(http.HandlerFunc(getDummyWeights))
+			r := genLinearRegressor(types.AbsComponentModelWeight, testServer.URL, "ComponentModelWeight")
+			Expect(r.Start()).To(BeNil())
+			r.ResetSampleIdx()
+			r.AddNodeFeatureValues(nodeFeatureValues)
+			compPowers, compUncertainties, err := r.GetComponentsPowerWithUncertainty(false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(compPowers[0].Core).Should(BeEquivalentTo(4000))
+			Expect(compUncertainties[0].Core).Should(BeNumerically(">", 0))
+		})
 	})
 
 	Context("without model server", func() {
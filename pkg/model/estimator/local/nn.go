@@ -0,0 +1,182 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+nn.go
+estimate (node/pod) component and total power with a small feed-forward
+neural network (matmul + bias + activation per layer) when trained weights
+are available. The weights are fetched the same way as LinearRegressor's,
+just with a different JSON schema.
+*/
+
+package local
+
+import (
+	"fmt"
+
+	"github.com/sustainable-computing-io/kepler/pkg/model/types"
+)
+
+// NNLayer is one fully-connected layer: out = activation(W*x + B).
+type NNLayer struct {
+	W          [][]float64 `json:"W"`
+	B          []float64   `json:"B"`
+	Activation string      `json:"Activation"`
+}
+
+// NeuralNetWeights is a trained feed-forward network: one NNLayer per
+// hidden/output layer, evaluated in order.
+type NeuralNetWeights struct {
+	Layers []NNLayer `json:"Layers"`
+}
+
+// NeuralNetRegressor is the feed-forward-network PowerEstimator. Its input
+// vector is a sample's raw numerical feature values, in FloatFeatureNames
+// order, with no normalization; the categorical fields are only sent in the
+// ModelRequest and are not part of the network's input vector.
+type NeuralNetRegressor struct {
+	ModelServerEndpoint         string
+	OutputType                  types.ModelOutputType
+	FloatFeatureNames           []string
+	SystemMetaDataFeatureNames  []string
+	SystemMetaDataFeatureValues []string
+	ModelWeightsURL             string
+
+	modelWeights          NeuralNetWeights
+	componentModelWeights map[string]NeuralNetWeights
+	sampleValues          [][]float64
+}
+
+var _ PowerEstimator = (*NeuralNetRegressor)(nil)
+
+func (r *NeuralNetRegressor) isComponentOutput() bool {
+	return r.OutputType == types.AbsComponentModelWeight || r.OutputType == types.DynComponentModelWeight
+}
+
+// Start fetches the trained weights for r.OutputType, either from the
+// Kepler Model Server (when config.ModelServerEnable is set and
+// ModelServerEndpoint is non-empty) or from ModelWeightsURL.
+func (r *NeuralNetRegressor) Start() error {
+	req := ModelRequest{
+		FloatFeatureNames:           r.FloatFeatureNames,
+		SystemMetaDataFeatureNames:  r.SystemMetaDataFeatureNames,
+		SystemMetaDataFeatureValues: r.SystemMetaDataFeatureValues,
+		OutputType:                  r.OutputType.String(),
+	}
+	if r.isComponentOutput() {
+		return fetchModelWeights(r.ModelServerEndpoint, r.ModelWeightsURL, req, &r.componentModelWeights)
+	}
+	return fetchModelWeights(r.ModelServerEndpoint, r.ModelWeightsURL, req, &r.modelWeights)
+}
+
+// ResetSampleIdx discards all samples added so far.
+func (r *NeuralNetRegressor) ResetSampleIdx() {
+	r.sampleValues = nil
+}
+
+// AddNodeFeatureValues appends one node-level sample to estimate.
+func (r *NeuralNetRegressor) AddNodeFeatureValues(values []float64) {
+	r.sampleValues = append(r.sampleValues, values)
+}
+
+// AddContainerFeatureValues appends one container-level sample to estimate.
+func (r *NeuralNetRegressor) AddContainerFeatureValues(values []float64) {
+	r.sampleValues = append(r.sampleValues, values)
+}
+
+// GetPlatformPower returns one platform power estimate (in Watts) per
+// sample added since the last ResetSampleIdx.
+func (r *NeuralNetRegressor) GetPlatformPower(isIdlePower bool) ([]float64, error) {
+	if len(r.modelWeights.Layers) == 0 {
+		return nil, fmt.Errorf("no platform power model weights loaded")
+	}
+	powers := make([]float64, len(r.sampleValues))
+	for i, values := range r.sampleValues {
+		power, err := forwardNN(r.modelWeights, values)
+		if err != nil {
+			return nil, err
+		}
+		powers[i] = power
+	}
+	return powers, nil
+}
+
+// GetComponentsPower returns one per-component power estimate (in
+// milliWatts) per sample added since the last ResetSampleIdx.
+func (r *NeuralNetRegressor) GetComponentsPower(isIdlePower bool) ([]ComponentPower, error) {
+	if len(r.componentModelWeights) == 0 {
+		return nil, fmt.Errorf("no component power model weights loaded")
+	}
+	powers := make([]ComponentPower, len(r.sampleValues))
+	for i, values := range r.sampleValues {
+		var cp ComponentPower
+		for component, weights := range r.componentModelWeights {
+			if len(weights.Layers) == 0 {
+				continue
+			}
+			power, err := forwardNN(weights, values)
+			if err != nil {
+				return nil, err
+			}
+			setComponentPower(&cp, component, power*platformToComponentScale)
+		}
+		powers[i] = cp
+	}
+	return powers, nil
+}
+
+// forwardNN runs input through every layer of w in order, applying each
+// layer's activation to its (W*x + B) output. It errors instead of panicking
+// when a layer's W has fewer rows than B, e.g. from a malformed or corrupted
+// weights payload.
+func forwardNN(w NeuralNetWeights, input []float64) (float64, error) {
+	x := input
+	for _, layer := range w.Layers {
+		out := make([]float64, len(layer.B))
+		for i := range out {
+			if i >= len(layer.W) {
+				return 0, fmt.Errorf("neural net weights malformed: layer has %d output(s) but only %d row(s) in W", len(layer.B), len(layer.W))
+			}
+			sum := layer.B[i]
+			for j, xv := range x {
+				if j < len(layer.W[i]) {
+					sum += layer.W[i][j] * xv
+				}
+			}
+			out[i] = applyActivation(layer.Activation, sum)
+		}
+		x = out
+	}
+	if len(x) == 0 {
+		return 0, nil
+	}
+	return x[0], nil
+}
+
+// applyActivation applies the named activation function elementwise.
+// Unknown/empty names (e.g. the final linear layer) pass the value through
+// unchanged.
+func applyActivation(name string, v float64) float64 {
+	switch name {
+	case "relu":
+		if v < 0 {
+			return 0
+		}
+		return v
+	default:
+		return v
+	}
+}
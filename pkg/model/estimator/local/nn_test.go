@@ -0,0 +1,156 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/sustainable-computing-io/kepler/pkg/config"
+	"github.com/sustainable-computing-io/kepler/pkg/model/types"
+)
+
+var (
+	SampleNNWeights = NeuralNetWeights{
+		Layers: []NNLayer{
+			{
+				W:          [][]float64{{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, {-1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+				B:          []float64{0, 0},
+				Activation: "relu",
+			},
+			{
+				W:          [][]float64{{1, 1}},
+				B:          []float64{0},
+				Activation: "",
+			},
+		},
+	}
+	SampleComponentNNWeightResponse = map[string]NeuralNetWeights{
+		"core": SampleNNWeights,
+		"dram": SampleNNWeights,
+	}
+)
+
+func getDummyNNWeights(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		panic(err)
+	}
+	var req ModelRequest
+	if err := json.Unmarshal(reqBody, &req); err != nil {
+		panic(err)
+	}
+	if strings.Contains(req.OutputType, "ComponentModelWeight") {
+		err = json.NewEncoder(w).Encode(SampleComponentNNWeightResponse)
+	} else {
+		err = json.NewEncoder(w).Encode(SampleNNWeights)
+	}
+	if err != nil {
+		panic(err)
+	}
+}
+
+func genNeuralNetRegressor(outputType types.ModelOutputType, modelServerEndpoint string) NeuralNetRegressor {
+	config.ModelServerEnable = true
+	config.ModelServerEndpoint = modelServerEndpoint
+	return NeuralNetRegressor{
+		ModelServerEndpoint:         modelServerEndpoint,
+		OutputType:                  outputType,
+		FloatFeatureNames:           containerFeatureNames,
+		SystemMetaDataFeatureNames:  systemMetaDataFeatureNames,
+		SystemMetaDataFeatureValues: systemMetaDataFeatureValues,
+	}
+}
+
+var _ = Describe("Test NN Weight Unit", func() {
+	Context("with dummy model server", func() {
+		It("Get Node Platform Power By Neural Network with ModelServerEndpoint", func() {
+			testServer := httptest.NewServer(http.HandlerFunc(getDummyNNWeights))
+			r := genNeuralNetRegressor(types.AbsModelWeight, testServer.URL)
+			Expect(r.Start()).To(BeNil())
+			r.ResetSampleIdx()
+			r.AddNodeFeatureValues(nodeFeatureValues)
+			powers, err := r.GetPlatformPower(false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(powers)).Should(Equal(1))
+			Expect(powers[0]).Should(BeEquivalentTo(2))
+		})
+
+		It("Get Node Components Power By Neural Network with ModelServerEndpoint", func() {
+			testServer := httptest.NewServer(http.HandlerFunc(getDummyNNWeights))
+			r := genNeuralNetRegressor(types.AbsComponentModelWeight, testServer.URL)
+			Expect(r.Start()).To(BeNil())
+			r.ResetSampleIdx()
+			r.AddNodeFeatureValues(nodeFeatureValues)
+			compPowers, err := r.GetComponentsPower(false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(compPowers)).Should(Equal(1))
+			Expect(compPowers[0].Core).Should(BeEquivalentTo(2000))
+		})
+
+		It("Get Container Platform Power By Neural Network with ModelServerEndpoint", func() {
+			testServer := httptest.NewServer(http.HandlerFunc(getDummyNNWeights))
+			r := genNeuralNetRegressor(types.DynModelWeight, testServer.URL)
+			Expect(r.Start()).To(BeNil())
+			r.ResetSampleIdx()
+			for _, values := range containerFeatureValues {
+				r.AddContainerFeatureValues(values)
+			}
+			powers, err := r.GetPlatformPower(false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(powers)).Should(Equal(len(containerFeatureValues)))
+			Expect(powers[0]).Should(BeEquivalentTo(1))
+		})
+
+		It("Get Container Components Power By Neural Network with ModelServerEndpoint", func() {
+			testServer := httptest.NewServer(http.HandlerFunc(getDummyNNWeights))
+			r := genNeuralNetRegressor(types.DynComponentModelWeight, testServer.URL)
+			Expect(r.Start()).To(BeNil())
+			r.ResetSampleIdx()
+			for _, values := range containerFeatureValues {
+				r.AddContainerFeatureValues(values)
+			}
+			compPowers, err := r.GetComponentsPower(false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(compPowers)).Should(Equal(len(containerFeatureValues)))
+			Expect(compPowers[0].Core).Should(BeEquivalentTo(1000))
+		})
+	})
+
+	Context("with malformed weights", func() {
+		It("errors instead of panicking when a layer's W has fewer rows than B", func() {
+			r := NeuralNetRegressor{
+				FloatFeatureNames: containerFeatureNames,
+			}
+			r.modelWeights = NeuralNetWeights{
+				Layers: []NNLayer{
+					{W: [][]float64{{1, 2}}, B: []float64{0, 0}, Activation: "relu"},
+				},
+			}
+			r.ResetSampleIdx()
+			r.AddNodeFeatureValues(nodeFeatureValues)
+			_, err := r.GetPlatformPower(false)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
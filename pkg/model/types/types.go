@@ -0,0 +1,67 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package types holds the request/response vocabulary shared between the
+// power estimators in pkg/model and the Kepler Model Server.
+package types
+
+// ModelOutputType identifies which shape of weights an estimator is asking
+// the Model Server (or a static ModelWeightsURL) for.
+type ModelOutputType int
+
+const (
+	// AbsPower is the absolute (node) platform power output.
+	AbsPower ModelOutputType = iota
+	// AbsModelWeight is the absolute platform power model weights.
+	AbsModelWeight
+	// AbsComponentModelWeight is the absolute per-component power model weights.
+	AbsComponentModelWeight
+	// DynPower is the dynamic (container) platform power output.
+	DynPower
+	// DynModelWeight is the dynamic platform power model weights.
+	DynModelWeight
+	// DynComponentModelWeight is the dynamic per-component power model weights.
+	DynComponentModelWeight
+)
+
+var modelOutputTypeName = map[ModelOutputType]string{
+	AbsPower:                "AbsPower",
+	AbsModelWeight:          "AbsModelWeight",
+	AbsComponentModelWeight: "AbsComponentModelWeight",
+	DynPower:                "DynPower",
+	DynModelWeight:          "DynModelWeight",
+	DynComponentModelWeight: "DynComponentModelWeight",
+}
+
+// String returns the wire name used in ModelRequest.OutputType.
+func (t ModelOutputType) String() string {
+	return modelOutputTypeName[t]
+}
+
+// EstimatorType selects which regression backend a PowerEstimator uses to
+// turn feature values into a power number. It is independent of
+// ModelOutputType, which only describes the shape of the weights being
+// requested (platform vs. per-component, absolute vs. dynamic).
+type EstimatorType string
+
+const (
+	// LinearRegressorType is the bias + weighted-sum estimator.
+	LinearRegressorType EstimatorType = "linear"
+	// NeuralNetworkType is the feed-forward (matmul + ReLU) estimator.
+	NeuralNetworkType EstimatorType = "neuralnet"
+	// GradientBoostingTreeType is the summed-regression-tree estimator.
+	GradientBoostingTreeType EstimatorType = "gbt"
+)